@@ -6,11 +6,10 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
+	"encoding/pem"
 	"fmt"
-	"net"
 	"net/url"
-	"strconv"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -19,7 +18,10 @@ import (
 	"github.com/hashicorp/vault/plugins/helper/database/connutil"
 	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
 	"github.com/mitchellh/mapstructure"
-	"gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 // mongoDBConnectionProducer implements ConnectionProducer and provides an
@@ -34,11 +36,28 @@ type mongoDBConnectionProducer struct {
 	TLSCA         string `json:"tls_ca" structs:"tls_ca" mapstructure:"tls_ca"`
 	TLSVerify     string `json:"tls_verify" structs:"tls_verify" mapstructure:"tls_verify"`
 
-	Initialized bool
-	RawConfig   map[string]interface{}
-	Type        string
-	session     *mgo.Session
-	safe        *mgo.Safe
+	// AuthMechanism is one of SCRAM-SHA-1, SCRAM-SHA-256, MONGODB-X509,
+	// GSSAPI, or PLAIN. GSSAPI additionally requires this plugin to be
+	// built with -tags gssapi against a Kerberos-enabled mongo-driver.
+	AuthMechanism     string `json:"authentication_mechanism" structs:"authentication_mechanism" mapstructure:"authentication_mechanism"`
+	AuthSource        string `json:"auth_source" structs:"auth_source" mapstructure:"auth_source"`
+	GSSAPIServiceName string `json:"gssapi_service_name" structs:"gssapi_service_name" mapstructure:"gssapi_service_name"`
+	KerberosPrincipal string `json:"kerberos_principal" structs:"kerberos_principal" mapstructure:"kerberos_principal"`
+	KerberosKeytab    string `json:"kerberos_keytab" structs:"kerberos_keytab" mapstructure:"kerberos_keytab"`
+	KerberosCCacheEnv string `json:"kerberos_ccache_env" structs:"kerberos_ccache_env" mapstructure:"kerberos_ccache_env"`
+
+	ReadPreference         string `json:"read_preference" structs:"read_preference" mapstructure:"read_preference"`
+	ServerSelectionTimeout int    `json:"server_selection_timeout" structs:"server_selection_timeout" mapstructure:"server_selection_timeout"`
+	SocketTimeout          int    `json:"socket_timeout" structs:"socket_timeout" mapstructure:"socket_timeout"`
+	SyncTimeout            int    `json:"sync_timeout" structs:"sync_timeout" mapstructure:"sync_timeout"`
+	HeartbeatInterval      int    `json:"heartbeat_interval" structs:"heartbeat_interval" mapstructure:"heartbeat_interval"`
+	MinPoolSize            uint64 `json:"min_pool_size" structs:"min_pool_size" mapstructure:"min_pool_size"`
+
+	Initialized  bool
+	RawConfig    map[string]interface{}
+	Type         string
+	client       *mongo.Client
+	writeConcern *writeconcern.WriteConcern
 	sync.Mutex
 }
 
@@ -69,27 +88,11 @@ func (c *mongoDBConnectionProducer) Init(ctx context.Context, conf map[string]in
 	})
 
 	if c.WriteConcern != "" {
-		input := c.WriteConcern
-
-		// Try to base64 decode the input. If successful, consider the decoded
-		// value as input.
-		inputBytes, err := base64.StdEncoding.DecodeString(input)
-		if err == nil {
-			input = string(inputBytes)
-		}
-
-		concern := &mgo.Safe{}
-		err = json.Unmarshal([]byte(input), concern)
+		concern, err := parseWriteConcern(c.WriteConcern)
 		if err != nil {
-			return nil, errwrap.Wrapf("error mashalling write_concern: {{err}}", err)
+			return nil, err
 		}
-
-		// Guard against empty, non-nil mgo.Safe object; we don't want to pass that
-		// into mgo.SetSafe in Connection().
-		if (mgo.Safe{} == *concern) {
-			return nil, fmt.Errorf("provided write_concern values did not map to any mgo.Safe fields")
-		}
-		c.safe = concern
+		c.writeConcern = concern
 	}
 
 	// Set initialized to true at this point since all fields are set,
@@ -97,11 +100,12 @@ func (c *mongoDBConnectionProducer) Init(ctx context.Context, conf map[string]in
 	c.Initialized = true
 
 	if verifyConnection {
-		if _, err := c.Connection(ctx); err != nil {
+		client, err := c.Connection(ctx)
+		if err != nil {
 			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
 		}
 
-		if err := c.session.Ping(); err != nil {
+		if err := client.(*mongo.Client).Ping(ctx, nil); err != nil {
 			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
 		}
 	}
@@ -109,37 +113,229 @@ func (c *mongoDBConnectionProducer) Init(ctx context.Context, conf map[string]in
 	return conf, nil
 }
 
-// Connection creates or returns an existing a database connection. If the session fails
-// on a ping check, the session will be closed and then re-created.
-func (c *mongoDBConnectionProducer) Connection(_ context.Context) (interface{}, error) {
+// Connection creates or returns an existing database connection. If the
+// client fails a ping check, it is disconnected and a fresh one is dialed.
+func (c *mongoDBConnectionProducer) Connection(ctx context.Context) (interface{}, error) {
 	if !c.Initialized {
 		return nil, connutil.ErrNotInitialized
 	}
 
-	if c.session != nil {
-		if err := c.session.Ping(); err == nil {
-			return c.session, nil
+	if c.client != nil {
+		if err := c.client.Ping(ctx, nil); err == nil {
+			return c.client, nil
 		}
-		c.session.Close()
+		c.client.Disconnect(ctx)
 	}
 
-	dialInfo, err := parseMongoURL(c.ConnectionURL, c.TLSCert, c.TLSKey, c.TLSCA, c.TLSVerify)
+	clientOpts, err := c.clientOptions()
 	if err != nil {
 		return nil, err
 	}
 
+	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
-		return nil, err
+		return nil, errwrap.Wrapf("error creating mongo client: {{err}}", err)
+	}
+
+	c.client = client
+
+	return c.client, nil
+}
+
+// clientOptions builds the options.ClientOptions used to dial MongoDB from
+// the producer's connection_url and TLS configuration.
+func (c *mongoDBConnectionProducer) clientOptions() (*options.ClientOptions, error) {
+	clientOpts := options.Client().ApplyURI(c.ConnectionURL)
+	if err := clientOpts.Validate(); err != nil {
+		return nil, errwrap.Wrapf("invalid connection_url: {{err}}", err)
+	}
+
+	if c.TLSCA != "" {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		clientOpts.SetTLSConfig(tlsConfig)
+	}
+
+	if c.writeConcern != nil {
+		clientOpts.SetWriteConcern(c.writeConcern)
+	}
+
+	if c.AuthMechanism != "" {
+		cred, err := c.authCredential()
+		if err != nil {
+			return nil, err
+		}
+		clientOpts.SetAuth(cred)
+	}
+
+	if c.ReadPreference != "" {
+		rp, err := parseReadPreference(c.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		clientOpts.SetReadPreference(rp)
+	}
+
+	if c.ServerSelectionTimeout > 0 {
+		clientOpts.SetServerSelectionTimeout(time.Duration(c.ServerSelectionTimeout) * time.Second)
+	}
+
+	if c.SocketTimeout > 0 {
+		clientOpts.SetSocketTimeout(time.Duration(c.SocketTimeout) * time.Second)
+	}
+
+	if c.SyncTimeout > 0 {
+		// sync_timeout is carried over from the mgo-based producer's
+		// SetSyncTimeout; the closest equivalent in the driver's
+		// connection-pooled model is how long to wait for a connection to
+		// be established.
+		clientOpts.SetConnectTimeout(time.Duration(c.SyncTimeout) * time.Second)
+	}
+
+	if c.HeartbeatInterval > 0 {
+		clientOpts.SetHeartbeatInterval(time.Duration(c.HeartbeatInterval) * time.Second)
+	}
+
+	if c.MinPoolSize > 0 {
+		clientOpts.SetMinPoolSize(c.MinPoolSize)
+	}
+
+	return clientOpts, nil
+}
+
+// parseReadPreference maps the read_preference config string onto a
+// *readpref.ReadPref, matching the modes the MongoDB drivers themselves
+// accept in a connection string's readPreference option.
+func parseReadPreference(mode string) (*readpref.ReadPref, error) {
+	switch mode {
+	case "primary":
+		return readpref.Primary(), nil
+	case "primaryPreferred":
+		return readpref.PrimaryPreferred(), nil
+	case "secondary":
+		return readpref.Secondary(), nil
+	case "secondaryPreferred":
+		return readpref.SecondaryPreferred(), nil
+	case "nearest":
+		return readpref.Nearest(), nil
+	default:
+		return nil, fmt.Errorf("invalid read_preference: %s", mode)
+	}
+}
+
+// authCredential builds an options.Credential from the producer's
+// authentication_mechanism and related fields, for auth modes beyond the
+// SCRAM username/password already carried in the connection_url.
+func (c *mongoDBConnectionProducer) authCredential() (options.Credential, error) {
+	cred := options.Credential{
+		AuthMechanism: c.AuthMechanism,
+		AuthSource:    c.AuthSource,
+		Username:      c.Username,
+		Password:      c.Password,
+		PasswordSet:   c.Password != "",
+	}
+
+	switch c.AuthMechanism {
+	case "MONGODB-X509":
+		if c.TLSCA == "" || c.TLSCert == "" || c.TLSKey == "" {
+			return options.Credential{}, fmt.Errorf("authentication_mechanism MONGODB-X509 requires tls_ca, tls_cert, and tls_key")
+		}
+		if cred.Username == "" {
+			subject, err := x509SubjectDN(c.TLSCert)
+			if err != nil {
+				return options.Credential{}, errwrap.Wrapf("error deriving username from tls_cert: {{err}}", err)
+			}
+			cred.Username = subject
+		}
+		cred.Password = ""
+		cred.PasswordSet = false
+
+	case "GSSAPI":
+		if !gssapiBuildSupported {
+			return options.Credential{}, fmt.Errorf("authentication_mechanism GSSAPI requires a build of this plugin compiled with -tags gssapi against a Kerberos-enabled mongo-driver; see the gssapi build tag in this package")
+		}
+
+		cred.AuthMechanismProperties = map[string]string{}
+		if c.GSSAPIServiceName != "" {
+			cred.AuthMechanismProperties["SERVICE_NAME"] = c.GSSAPIServiceName
+		}
+		if c.KerberosPrincipal != "" {
+			cred.Username = c.KerberosPrincipal
+		}
+
+		// GSSAPI authenticates against whatever Kerberos credentials cache
+		// is active for the process. Operators can point us at a
+		// pre-obtained cache via an environment variable, whose value is
+		// exported as KRB5CCNAME, or otherwise at a keytab, which is
+		// exported as KRB5_CLIENT_KTNAME. We don't mint a cache ourselves.
+		if c.KerberosCCacheEnv != "" {
+			if ccache := os.Getenv(c.KerberosCCacheEnv); ccache != "" {
+				if err := os.Setenv("KRB5CCNAME", ccache); err != nil {
+					return options.Credential{}, errwrap.Wrapf("error setting KRB5CCNAME: {{err}}", err)
+				}
+			}
+		} else if c.KerberosKeytab != "" {
+			if err := os.Setenv("KRB5_CLIENT_KTNAME", c.KerberosKeytab); err != nil {
+				return options.Credential{}, errwrap.Wrapf("error setting KRB5_CLIENT_KTNAME: {{err}}", err)
+			}
+		}
+
+		cred.Password = ""
+		cred.PasswordSet = false
 	}
 
-	if c.safe != nil {
-		c.session.SetSafe(c.safe)
+	return cred, nil
+}
+
+// x509SubjectDN parses a PEM-encoded client certificate and returns its
+// subject distinguished name, used as the MONGODB-X509 username when the
+// operator hasn't supplied one explicitly.
+func x509SubjectDN(certPEM string) (string, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return "", fmt.Errorf("tls_cert does not contain a PEM-encoded certificate")
 	}
 
-	c.session.SetSyncTimeout(1 * time.Minute)
-	c.session.SetSocketTimeout(1 * time.Minute)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
 
-	return c.session, nil
+	return cert.Subject.String(), nil
+}
+
+// tlsConfig builds a *tls.Config from the producer's tls_ca/tls_cert/tls_key/
+// tls_verify fields, for use when the connection_url requests TLS but needs
+// a custom root CA or a client certificate that the driver's own ApplyURI
+// handling of tlsCAFile/tlsCertificateKeyFile can't source from raw PEM.
+func (c *mongoDBConnectionProducer) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	caCerts := x509.NewCertPool()
+	if ok := caCerts.AppendCertsFromPEM([]byte(c.TLSCA)); !ok {
+		return nil, fmt.Errorf("failed to parse tls_ca value")
+	}
+	tlsConfig.RootCAs = caCerts
+
+	if c.TLSCert != "" && c.TLSKey != "" {
+		clientCert, err := tls.X509KeyPair([]byte(c.TLSCert), []byte(c.TLSKey))
+		if err != nil {
+			return nil, errwrap.Wrapf("bad value for tls_cert or tls_key: {{err}}", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	if c.TLSVerify != "" {
+		insecureSkipVerify, err := parseBool(c.TLSVerify)
+		if err != nil {
+			return nil, errwrap.Wrapf("bad value for tls_verify: {{err}}", err)
+		}
+		tlsConfig.InsecureSkipVerify = insecureSkipVerify
+	}
+
+	return tlsConfig, nil
 }
 
 // Close terminates the database connection.
@@ -147,109 +343,105 @@ func (c *mongoDBConnectionProducer) Close() error {
 	c.Lock()
 	defer c.Unlock()
 
-	if c.session != nil {
-		c.session.Close()
+	if c.client != nil {
+		if err := c.client.Disconnect(context.Background()); err != nil {
+			return err
+		}
 	}
 
-	c.session = nil
+	c.client = nil
 
 	return nil
 }
 
-func parseMongoURL(rawURL, tlsCert, tlsKey, tlsCA, tlsVerify string) (*mgo.DialInfo, error) {
-	url, err := url.Parse(rawURL)
-	if err != nil {
-		return nil, err
-	}
+// writeConcernConfig mirrors the JSON shape operators have historically
+// supplied in the write_concern field (the same shape mgo.Safe accepted),
+// which we translate into a *writeconcern.WriteConcern. fsync and rtimeout
+// are decoded but have no effect on the resulting write concern.
+type writeConcernConfig struct {
+	W        interface{} `json:"w"`
+	WMode    string      `json:"wmode"`
+	RTimeout int         `json:"rtimeout"`
+	WTimeout int         `json:"wtimeout"`
+	FSync    bool        `json:"fsync"`
+	J        bool        `json:"j"`
+}
 
-	info := mgo.DialInfo{
-		Addrs:    strings.Split(url.Host, ","),
-		Database: strings.TrimPrefix(url.Path, "/"),
-		Timeout:  10 * time.Second,
+func parseWriteConcern(raw string) (*writeconcern.WriteConcern, error) {
+	input := raw
+
+	// Try to base64 decode the input. If successful, consider the decoded
+	// value as input.
+	inputBytes, err := base64.StdEncoding.DecodeString(input)
+	if err == nil {
+		input = string(inputBytes)
 	}
 
-	if url.User != nil {
-		info.Username = url.User.Username()
-		info.Password, _ = url.User.Password()
+	var cfg writeConcernConfig
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		return nil, errwrap.Wrapf("error unmarshalling write_concern: {{err}}", err)
 	}
 
-	query := url.Query()
-	for key, values := range query {
-		var value string
-		if len(values) > 0 {
-			value = values[0]
-		}
+	if cfg.W == nil && cfg.WMode == "" && !cfg.FSync && !cfg.J && cfg.WTimeout == 0 {
+		return nil, fmt.Errorf("provided write_concern values did not map to any write concern fields")
+	}
 
-		switch key {
-		case "authSource":
-			info.Source = value
-		case "authMechanism":
-			info.Mechanism = value
-		case "gssapiServiceName":
-			info.Service = value
-		case "replicaSet":
-			info.ReplicaSetName = value
-		case "maxPoolSize":
-			poolLimit, err := strconv.Atoi(value)
-			if err != nil {
-				return nil, errors.New("bad value for maxPoolSize: " + value)
+	opts := []writeconcern.Option{}
+	switch {
+	case cfg.WMode == "majority":
+		opts = append(opts, writeconcern.WMajority())
+	case cfg.WMode != "":
+		opts = append(opts, writeconcern.WTagSet(cfg.WMode))
+	case cfg.W != nil:
+		switch w := cfg.W.(type) {
+		case float64:
+			opts = append(opts, writeconcern.W(int(w)))
+		case string:
+			if w == "majority" {
+				opts = append(opts, writeconcern.WMajority())
+			} else {
+				opts = append(opts, writeconcern.WTagSet(w))
 			}
-			info.PoolLimit = poolLimit
-		case "ssl":
-			// Unfortunately, mgo doesn't support the ssl parameter in its MongoDB URI parsing logic, so we have to handle that
-			// ourselves. See https://github.com/go-mgo/mgo/issues/84
-			ssl, err := strconv.ParseBool(value)
-			if err != nil {
-				return nil, errors.New("bad value for ssl: " + value)
-			}
-			if ssl {
-				info.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
-					tlsConfig := &tls.Config{}
-					if tlsCert != "" && tlsKey != "" && tlsCA != "" {
-						caCerts := x509.NewCertPool()
-						ok := caCerts.AppendCertsFromPEM([]byte(tlsCA))
-						if !ok {
-							return nil, errors.New("failed to parse tls_ca value")
-						}
-						clientCert, err := tls.X509KeyPair([]byte(tlsCert), []byte(tlsKey))
-						if err != nil {
-							return nil, errors.New("bad value for tls_cert or tls_key")
-						}
-						clientCert.Leaf, err = x509.ParseCertificate(clientCert.Certificate[0])
-						if err != nil {
-							return nil, errors.New("failed to parse tls_cert or tls_key")
-						}
-						insecureSkipVerify := false
-						if tlsVerify != "" {
-							insecureSkipVerify, err = strconv.ParseBool(tlsVerify)
-							if err != nil {
-								return nil, errors.New("bad value for tls verify: " + tlsVerify)
-							}
-						}
-						tlsConfig = &tls.Config{
-							Certificates:       []tls.Certificate{clientCert},
-							RootCAs:            caCerts,
-							InsecureSkipVerify: insecureSkipVerify,
-						}
-					}
-					return tls.Dial("tcp", addr.String(), tlsConfig)
-				}
-			}
-		case "connect":
-			if value == "direct" {
-				info.Direct = true
-				break
-			}
-			if value == "replicaSet" {
-				break
-			}
-			fallthrough
-		default:
-			return nil, errors.New("unsupported connection URL option: " + key + "=" + value)
 		}
 	}
 
-	return &info, nil
+	if cfg.J {
+		opts = append(opts, writeconcern.J(true))
+	}
+
+	if cfg.WTimeout > 0 {
+		opts = append(opts, writeconcern.WTimeout(time.Duration(cfg.WTimeout)*time.Millisecond))
+	}
+
+	return writeconcern.New(opts...), nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch s {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean value: %s", s)
+	}
+}
+
+// getDefaultAuthDB returns the database named in the connection_url's path,
+// defaulting to "admin" when none was given, matching mongo's own default
+// auth source.
+func (c *mongoDBConnectionProducer) getDefaultAuthDB() string {
+	connURL, err := url.Parse(c.ConnectionURL)
+	if err != nil {
+		return "admin"
+	}
+
+	db := strings.TrimPrefix(connURL.Path, "/")
+	if db == "" {
+		return "admin"
+	}
+
+	return db
 }
 
 func (c *mongoDBConnectionProducer) secretValues() map[string]interface{} {
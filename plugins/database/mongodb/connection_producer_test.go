@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestParseReadPreference(t *testing.T) {
+	tests := map[string]struct {
+		mode    string
+		want    readpref.Mode
+		wantErr bool
+	}{
+		"primary":            {mode: "primary", want: readpref.PrimaryMode},
+		"primaryPreferred":   {mode: "primaryPreferred", want: readpref.PrimaryPreferredMode},
+		"secondary":          {mode: "secondary", want: readpref.SecondaryMode},
+		"secondaryPreferred": {mode: "secondaryPreferred", want: readpref.SecondaryPreferredMode},
+		"nearest":            {mode: "nearest", want: readpref.NearestMode},
+		"invalid":            {mode: "whenever", wantErr: true},
+		"empty":              {mode: "", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseReadPreference(tc.mode)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseReadPreference(%q) expected error, got nil", tc.mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseReadPreference(%q) unexpected error: %v", tc.mode, err)
+			}
+			if got.Mode() != tc.want {
+				t.Fatalf("parseReadPreference(%q) mode = %v, want %v", tc.mode, got.Mode(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseWriteConcern(t *testing.T) {
+	tests := map[string]struct {
+		raw     string
+		wantErr bool
+	}{
+		"majority": {raw: `{"wmode": "majority"}`},
+		"numeric w with journal and timeout": {
+			raw: `{"w": 3, "j": true, "wtimeout": 5000}`,
+		},
+		"empty string":    {raw: "", wantErr: true},
+		"all zero values": {raw: `{}`, wantErr: true},
+		"invalid json":    {raw: `not json`, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			concern, err := parseWriteConcern(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseWriteConcern(%q) expected error, got nil", tc.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWriteConcern(%q) unexpected error: %v", tc.raw, err)
+			}
+			if concern == nil {
+				t.Fatalf("parseWriteConcern(%q) returned nil concern with no error", tc.raw)
+			}
+		})
+	}
+}
+
+func TestParseWriteConcernBase64(t *testing.T) {
+	// {"wmode": "majority"} base64-encoded, mirroring what the mgo-era
+	// write_concern field historically accepted.
+	encoded := "eyJ3bW9kZSI6ICJtYWpvcml0eSJ9"
+
+	concern, err := parseWriteConcern(encoded)
+	if err != nil {
+		t.Fatalf("parseWriteConcern(%q) unexpected error: %v", encoded, err)
+	}
+
+	_, data, err := concern.MarshalBSONValue()
+	if err != nil {
+		t.Fatalf("MarshalBSONValue: %v", err)
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("bson.Unmarshal: %v", err)
+	}
+	if doc["w"] != "majority" {
+		t.Fatalf("expected w=majority, got %v", doc["w"])
+	}
+}
+
+const testClientCertPEM = `-----BEGIN CERTIFICATE-----
+MIIDYTCCAkmgAwIBAgIUIe+dkbsREVYIaFqzWodPZ6gcq0gwDQYJKoZIhvcNAQEL
+BQAwQDERMA8GA1UEAwwIYXBwLXVzZXIxFDASBgNVBAsMC0VuZ2luZWVyaW5nMRUw
+EwYDVQQKDAxFeGFtcGxlIENvcnAwHhcNMjYwNzI1MjExMzA3WhcNMzYwNzIyMjEx
+MzA3WjBAMREwDwYDVQQDDAhhcHAtdXNlcjEUMBIGA1UECwwLRW5naW5lZXJpbmcx
+FTATBgNVBAoMDEV4YW1wbGUgQ29ycDCCASIwDQYJKoZIhvcNAQEBBQADggEPADCC
+AQoCggEBAMRMBJUmHV+lmVCZ7/WUaFtRjRJU8ROl0LBwyTJWmki6s2ZovLFWaeBb
+rrZoa4LaElGUrdrsgX1gyH9QfITLYIASy2Jrct0V+MBnuy/mM1+mc8djsWrwNPfs
+b693RRCqLEjvoU4cFMWT7U1G+f1vKdtNF8PTmDFxN8r8usWFO9Joo1DCw+yK3j5F
+Lz6naCbanHshS/Yhejxpv4yVcj9EuLA9MDsY4bosTi+dbc48pfuzA2jLYQm5zaRB
+JMNQvT7WN1rTD8eUoUPR3Mg5P6lRDUBckeWqHrSjKqnRssaOiLfuqEDPyjv2Tgh6
+WfENvvj2LMAO6Qko4sTTTXe/rbF+jBUCAwEAAaNTMFEwHQYDVR0OBBYEFJY4ZmSS
+S52F4LWN2VB4By57yzPZMB8GA1UdIwQYMBaAFJY4ZmSSS52F4LWN2VB4By57yzPZ
+MA8GA1UdEwEB/wQFMAMBAf8wDQYJKoZIhvcNAQELBQADggEBACSmd5Qlr3wg7aQa
+iSKrlM/c7nP0U/YoW/reHMSuqOzHDvk32jy+RAplsY7r22/IsKRnI+Yrm5qmmMf0
+Q2EQvYt5v4Npff7Comhpu0i2KDYXoxAZkHgceKNFiwVb/o7DHcoqRkB5BDSNCJ4c
+Mg5CXIieaXXBDOnNy2vgYoBieC3l3RQT0N6tEAGb9qYhSzUH+aI2lMHbd5mle0U+
+vp1ynjHyzEqt3N6pFucEdnqJdfkjTqTcvNnJf2eoaPZx5Ol7Ofgb6peLtBZTOXDo
+Tn+tk46mbkO/eq/BRowC2poCphP/+MSskI0TXhwy+OYijN8DT7SfkiVEhoswMTjO
+znZaAMs=
+-----END CERTIFICATE-----`
+
+func TestX509SubjectDN(t *testing.T) {
+	dn, err := x509SubjectDN(testClientCertPEM)
+	if err != nil {
+		t.Fatalf("x509SubjectDN: unexpected error: %v", err)
+	}
+
+	want := "CN=app-user,OU=Engineering,O=Example Corp"
+	if dn != want {
+		t.Fatalf("x509SubjectDN = %q, want %q", dn, want)
+	}
+}
+
+func TestX509SubjectDNInvalidPEM(t *testing.T) {
+	if _, err := x509SubjectDN("not a certificate"); err == nil {
+		t.Fatal("expected error for non-PEM input, got nil")
+	}
+}
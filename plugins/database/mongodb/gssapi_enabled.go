@@ -0,0 +1,8 @@
+//go:build gssapi
+
+package mongodb
+
+// gssapiBuildSupported is true when this plugin is built with -tags gssapi
+// against a mongo-driver/toolchain combination that links the system
+// Kerberos libraries, matching the driver's own gssapi build tag.
+const gssapiBuildSupported = true
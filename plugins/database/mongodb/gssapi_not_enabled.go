@@ -0,0 +1,11 @@
+//go:build !gssapi
+
+package mongodb
+
+// gssapiBuildSupported is false in the default build. The official driver's
+// own default build stubs out its GSSAPI authenticator (see
+// x/mongo/driver/auth/gssapi_not_enabled.go upstream) and always fails to
+// authenticate, so we refuse the config up front instead of failing
+// confusingly at dial time. Build with -tags gssapi against a driver and
+// toolchain that link the system Kerberos libraries to enable it.
+const gssapiBuildSupported = false
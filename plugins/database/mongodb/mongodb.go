@@ -0,0 +1,286 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
+	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const mongoDBTypeName = "mongodb"
+
+// MongoDB is a dbplugin.Database implementation for MongoDB, backed by the
+// official go.mongodb.org/mongo-driver client.
+type MongoDB struct {
+	*mongoDBConnectionProducer
+	credsutil.CredentialsProducer
+}
+
+var _ dbplugin.Database = &MongoDB{}
+
+// New returns a new, uninitialized MongoDB database plugin.
+func New() *MongoDB {
+	connProducer := &mongoDBConnectionProducer{}
+	connProducer.Type = mongoDBTypeName
+
+	credsProducer := &credsutil.SQLCredentialsProducer{
+		DisplayNameLen: 15,
+		RoleNameLen:    15,
+		UsernameLen:    100,
+		Separator:      "-",
+	}
+
+	return &MongoDB{
+		mongoDBConnectionProducer: connProducer,
+		CredentialsProducer:       credsProducer,
+	}
+}
+
+// Type returns the TypeName for this backend.
+func (m *MongoDB) Type() (string, error) {
+	return mongoDBTypeName, nil
+}
+
+func (m *MongoDB) getConnection(ctx context.Context) (*mongo.Client, error) {
+	db, err := m.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.(*mongo.Client), nil
+}
+
+// mongodbStatement is the JSON shape of a single entry in
+// Statements.Creation: the document handed to the createUser command.
+type mongodbStatement struct {
+	DB    string        `json:"db"`
+	Roles []interface{} `json:"roles"`
+}
+
+// CreateUser generates a new username/password pair for the given role and
+// runs the role's creation_statements createUser command against MongoDB.
+func (m *MongoDB) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+	if len(statements.Creation) == 0 {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	username, err = m.GenerateUsername(usernameConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err = m.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	client, err := m.getConnection(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := m.assertPrimaryAvailable(ctx); err != nil {
+		return "", "", err
+	}
+
+	rendered := make([]string, 0, len(statements.Creation))
+	for _, stmt := range statements.Creation {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		rendered = append(rendered, dbutil.QueryHelper(stmt, map[string]string{
+			"username": username,
+			"password": password,
+		}))
+	}
+
+	// Role documents are ensured before the user document so that the
+	// roles referenced by createUser already exist, regardless of where
+	// in creation_statements the operator listed them.
+	var userStmt *mongodbStatement
+	for _, stmt := range rendered {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(stmt), &raw); err != nil {
+			return "", "", errwrap.Wrapf("error unmarshalling creation_statements: {{err}}", err)
+		}
+
+		if isRoleStatement(raw) {
+			var roleStmt mongodbRoleStatement
+			if err := json.Unmarshal([]byte(stmt), &roleStmt); err != nil {
+				return "", "", errwrap.Wrapf("error unmarshalling createRole statement: {{err}}", err)
+			}
+			if err := m.ensureRole(ctx, client, roleStmt); err != nil {
+				return "", "", err
+			}
+			continue
+		}
+
+		if userStmt != nil {
+			return "", "", fmt.Errorf("creation_statements must contain exactly one user document, found a second: %s", stmt)
+		}
+
+		var cmd mongodbStatement
+		if err := json.Unmarshal([]byte(stmt), &cmd); err != nil {
+			return "", "", errwrap.Wrapf("error unmarshalling creation_statements: {{err}}", err)
+		}
+		userStmt = &cmd
+	}
+
+	if userStmt == nil {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	db := userStmt.DB
+	if db == "" {
+		db = m.mongoDBConnectionProducer.getDefaultAuthDB()
+	}
+
+	createUserCmd := bson.D{
+		{Key: "createUser", Value: username},
+		{Key: "pwd", Value: password},
+		{Key: "roles", Value: userStmt.Roles},
+	}
+
+	if err := client.Database(db).RunCommand(ctx, createUserCmd).Err(); err != nil {
+		return "", "", errwrap.Wrapf("error creating user: {{err}}", err)
+	}
+
+	return username, password, nil
+}
+
+// RenewUser is a no-op since MongoDB users do not have a notion of
+// expiration; Vault tracks and enforces the lease lifetime itself.
+func (m *MongoDB) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
+	return nil
+}
+
+// RevokeUser drops the given user from the database named in the role's
+// revocation_statements, falling back to the default auth database, then
+// tears down any roles revocation_statements says were created exclusively
+// for this user (a dropRole entry, named after the user via the usual
+// {{username}} templating).
+func (m *MongoDB) RevokeUser(ctx context.Context, statements dbplugin.Statements, username string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	client, err := m.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := m.mongoDBConnectionProducer.getDefaultAuthDB()
+	var dropRoles []mongodbDropRoleStatement
+
+	for _, stmt := range statements.Revocation {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		rendered := dbutil.QueryHelper(stmt, map[string]string{"username": username})
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(rendered), &raw); err != nil {
+			return errwrap.Wrapf("error unmarshalling revocation_statements: {{err}}", err)
+		}
+
+		if _, ok := raw["dropRole"]; ok {
+			var dropRole mongodbDropRoleStatement
+			if err := json.Unmarshal([]byte(rendered), &dropRole); err != nil {
+				return errwrap.Wrapf("error unmarshalling dropRole statement: {{err}}", err)
+			}
+			dropRoles = append(dropRoles, dropRole)
+			continue
+		}
+
+		var cmd mongodbStatement
+		if err := json.Unmarshal([]byte(rendered), &cmd); err == nil && cmd.DB != "" {
+			db = cmd.DB
+		}
+	}
+
+	dropUserCmd := bson.D{{Key: "dropUser", Value: username}}
+	if err := client.Database(db).RunCommand(ctx, dropUserCmd).Err(); err != nil {
+		return errwrap.Wrapf("error revoking user: {{err}}", err)
+	}
+
+	for _, dropRole := range dropRoles {
+		roleDB := dropRole.DB
+		if roleDB == "" {
+			roleDB = db
+		}
+		dropRoleCmd := bson.D{{Key: "dropRole", Value: dropRole.DropRole}}
+		if err := client.Database(roleDB).RunCommand(ctx, dropRoleCmd).Err(); err != nil {
+			return errwrap.Wrapf("error dropping role "+dropRole.DropRole+" for revoked user: {{err}}", err)
+		}
+	}
+
+	return nil
+}
+
+// RotateRootCredentials generates a new root password and applies it via
+// the updateUser command, returning the updated connection configuration.
+func (m *MongoDB) RotateRootCredentials(ctx context.Context, statements []string) (map[string]interface{}, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(m.Username) == 0 || len(m.Password) == 0 {
+		return nil, fmt.Errorf("attempting to rotate root credentials with no username and password")
+	}
+
+	newPassword, err := m.GeneratePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := m.getConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.assertPrimaryAvailable(ctx); err != nil {
+		return nil, err
+	}
+
+	updateUserCmd := bson.D{
+		{Key: "updateUser", Value: m.Username},
+		{Key: "pwd", Value: newPassword},
+	}
+
+	db := m.mongoDBConnectionProducer.getDefaultAuthDB()
+	if err := client.Database(db).RunCommand(ctx, updateUserCmd).Err(); err != nil {
+		return nil, errwrap.Wrapf("error rotating root credentials: {{err}}", err)
+	}
+
+	// The write above only lands on the primary; the follow-up check pings
+	// a server chosen by the operator's configured read preference
+	// (primary when none is set) instead of forcing another primary
+	// round-trip. It confirms that server is reachable; it does not read
+	// the updated user back.
+	rp := readpref.Primary()
+	if m.mongoDBConnectionProducer.ReadPreference != "" {
+		if configured, err := parseReadPreference(m.mongoDBConnectionProducer.ReadPreference); err == nil {
+			rp = configured
+		}
+	}
+	if err := client.Ping(ctx, rp); err != nil {
+		return nil, errwrap.Wrapf("error verifying rotated root credentials: {{err}}", err)
+	}
+
+	m.RawConfig["password"] = newPassword
+	m.Password = newPassword
+
+	return m.RawConfig, nil
+}
@@ -0,0 +1,88 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// roleAlreadyExistsCode is the MongoDB command error code returned by
+// createRole when a role with the given name already exists in the
+// database.
+const roleAlreadyExistsCode = 51002
+
+// mongodbRoleStatement is the JSON shape of a createRole entry in
+// Statements.Creation: any entry carrying a createRole key, wherever it
+// appears, is treated as a role that must exist before the user is created.
+type mongodbRoleStatement struct {
+	CreateRole string        `json:"createRole"`
+	DB         string        `json:"db"`
+	Privileges []interface{} `json:"privileges"`
+	Roles      []interface{} `json:"inheritsFrom"`
+}
+
+// isRoleStatement reports whether a decoded creation_statements entry
+// describes a role to ensure, as opposed to the user document.
+func isRoleStatement(raw map[string]interface{}) bool {
+	_, ok := raw["createRole"]
+	return ok
+}
+
+// ensureRole creates the role described by stmt if it doesn't already
+// exist, or updates it in place if it does, so that re-running the same
+// creation_statements is idempotent.
+func (m *MongoDB) ensureRole(ctx context.Context, client *mongo.Client, stmt mongodbRoleStatement) error {
+	db := stmt.DB
+	if db == "" {
+		db = m.mongoDBConnectionProducer.getDefaultAuthDB()
+	}
+
+	createCmd := bson.D{
+		{Key: "createRole", Value: stmt.CreateRole},
+		{Key: "privileges", Value: stmt.Privileges},
+		{Key: "roles", Value: stmt.Roles},
+	}
+
+	err := client.Database(db).RunCommand(ctx, createCmd).Err()
+	if err == nil {
+		return nil
+	}
+	if !isRoleExistsErr(err) {
+		return errwrap.Wrapf("error creating role "+stmt.CreateRole+": {{err}}", err)
+	}
+
+	updateCmd := bson.D{
+		{Key: "updateRole", Value: stmt.CreateRole},
+		{Key: "privileges", Value: stmt.Privileges},
+		{Key: "roles", Value: stmt.Roles},
+	}
+	if err := client.Database(db).RunCommand(ctx, updateCmd).Err(); err != nil {
+		return errwrap.Wrapf("error updating existing role "+stmt.CreateRole+": {{err}}", err)
+	}
+
+	return nil
+}
+
+// isRoleExistsErr reports whether err is the "role already exists" command
+// error createRole returns, either as a typed mongo.CommandError or, for
+// servers that report it as a plain text error, by matching the message.
+func isRoleExistsErr(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == roleAlreadyExistsCode
+	}
+	return strings.Contains(err.Error(), "already exists")
+}
+
+// mongodbDropRoleStatement is the JSON shape of an optional dropRole entry
+// in Statements.Revocation, used to tear down roles created exclusively
+// for a user (named after it via the usual {{username}} templating) when
+// that user's lease is revoked.
+type mongodbDropRoleStatement struct {
+	DropRole string `json:"dropRole"`
+	DB       string `json:"db"`
+}
@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsRoleStatement(t *testing.T) {
+	tests := map[string]struct {
+		raw  map[string]interface{}
+		want bool
+	}{
+		"createRole present": {
+			raw:  map[string]interface{}{"createRole": "app-role", "privileges": []interface{}{}},
+			want: true,
+		},
+		"user document": {
+			raw:  map[string]interface{}{"db": "app", "roles": []interface{}{"readWrite"}},
+			want: false,
+		},
+		"empty document": {
+			raw:  map[string]interface{}{},
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isRoleStatement(tc.raw); got != tc.want {
+				t.Fatalf("isRoleStatement(%v) = %v, want %v", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsRoleExistsErr(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"role already exists command error": {
+			err:  mongo.CommandError{Code: roleAlreadyExistsCode, Message: "Role \"app-role@app\" already exists"},
+			want: true,
+		},
+		"unrelated command error": {
+			err:  mongo.CommandError{Code: 13, Message: "not authorized on app to execute command"},
+			want: false,
+		},
+		"plain text already exists": {
+			err:  errors.New("role app-role@app already exists"),
+			want: true,
+		},
+		"plain text unrelated": {
+			err:  errors.New("connection refused"),
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isRoleExistsErr(tc.err); got != tc.want {
+				t.Fatalf("isRoleExistsErr(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// notRunningWithReplSetCode is the command error code replSetGetStatus
+// returns against a standalone mongod, which isn't a failure: there's just
+// no topology to report on.
+const notRunningWithReplSetCode = 76
+
+// replSetMember is the subset of a replSetGetStatus members[] entry we care
+// about for failover diagnostics.
+type replSetMember struct {
+	Name       string    `bson:"name"`
+	StateStr   string    `bson:"stateStr"`
+	OptimeDate time.Time `bson:"optimeDate"`
+}
+
+type replSetGetStatusResult struct {
+	Members []replSetMember `bson:"members"`
+}
+
+// Topology summarizes a replica set's health as seen from replSetGetStatus.
+// Tagged for JSON so it's ready to serialize once a transport carries it.
+type Topology struct {
+	Primary     string        `json:"primary"`
+	MemberCount int           `json:"member_count"`
+	MaxLag      time.Duration `json:"max_lag"`
+}
+
+// topology runs replSetGetStatus and summarizes the result. On a standalone
+// deployment (no replica set configured) it returns a zero-value Topology
+// and no error, since there's nothing to report.
+func (m *MongoDB) topology(ctx context.Context) (*Topology, error) {
+	client, err := m.getConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result replSetGetStatusResult
+	err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}}).Decode(&result)
+	if err != nil {
+		if isNotRunningWithReplSetErr(err) {
+			return &Topology{}, nil
+		}
+		return nil, errwrap.Wrapf("error querying replSetGetStatus: {{err}}", err)
+	}
+
+	top := &Topology{MemberCount: len(result.Members)}
+
+	var newestOptime time.Time
+	for _, member := range result.Members {
+		if member.StateStr == "PRIMARY" {
+			top.Primary = member.Name
+		}
+		if member.OptimeDate.After(newestOptime) {
+			newestOptime = member.OptimeDate
+		}
+	}
+
+	for _, member := range result.Members {
+		if lag := newestOptime.Sub(member.OptimeDate); lag > top.MaxLag {
+			top.MaxLag = lag
+		}
+	}
+
+	return top, nil
+}
+
+// Status returns the discovered replica set topology (primary host, member
+// count, replication lag) so operators can diagnose failover issues.
+//
+// This is as far as topology reporting can go from within this package:
+// dbplugin.Database, the interface Vault's database secrets engine talks
+// to over net/rpc, has no Status operation, and adding one means changing
+// that shared interface and its client/server shims, which live outside
+// plugins/database/mongodb. Exposing this over Vault's plugin RPC is a
+// separate, explicitly out-of-scope follow-up; until it lands, Status is
+// reachable only to Go code that holds a *MongoDB directly.
+func (m *MongoDB) Status(ctx context.Context) (*Topology, error) {
+	return m.topology(ctx)
+}
+
+// assertPrimaryAvailable confirms a reachable primary exists before a
+// write-path operation (user creation, root rotation) proceeds. Standalone
+// deployments, which have no replica set to check, are always allowed
+// through.
+func (m *MongoDB) assertPrimaryAvailable(ctx context.Context) error {
+	top, err := m.topology(ctx)
+	if err != nil {
+		return err
+	}
+
+	if top.MemberCount > 0 && top.Primary == "" {
+		return fmt.Errorf("no reachable primary found in replica set")
+	}
+
+	return nil
+}
+
+func isNotRunningWithReplSetErr(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == notRunningWithReplSetCode
+	}
+	return false
+}
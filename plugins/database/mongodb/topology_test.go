@@ -0,0 +1,36 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsNotRunningWithReplSetErr(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"standalone mongod": {
+			err:  mongo.CommandError{Code: notRunningWithReplSetCode, Message: "not running with --replSet"},
+			want: true,
+		},
+		"unrelated command error": {
+			err:  mongo.CommandError{Code: 13, Message: "not authorized on admin to execute command"},
+			want: false,
+		},
+		"non-command error": {
+			err:  errors.New("connection refused"),
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isNotRunningWithReplSetErr(tc.err); got != tc.want {
+				t.Fatalf("isNotRunningWithReplSetErr(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}